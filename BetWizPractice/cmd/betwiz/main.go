@@ -14,9 +14,12 @@ import (
 	"os/user"
 	"path/filepath"
 	"strings"
+	"sync"
+	"syscall"
 
 	_ "net/http/pprof"
 
+	"github.com/fsnotify/fsnotify"
 	"github.com/pelletier/go-toml/v2"
 )
 
@@ -44,6 +47,20 @@ func main(){
 	// Create a new instance of Main
 	m := NewMain()
 
+	// SIGHUP is the operator's manual alternative to the fsnotify watch
+	// started in Run() -- "kill -HUP <pid>" reloads config and routes in
+	// place without restarting the process. TLS provider changes still
+	// require a restart; see Main.Reload.
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			if err := m.Reload(ctx); err != nil {
+				log.Printf("reload: %s", err)
+			}
+		}
+	}()
+
 	// Parse command-line flags and handle errors
 	if err := m.ParseFlags(ctx, os.Args[1:]); err == flag.ErrHelp {
 		os.Exit(1)
@@ -76,6 +93,13 @@ type Main struct {
 
 	DB          *postgres.DB
 	HTTPServer  *http.Server
+
+	// scraperMu guards scraperCancel/scraperDone so startScraper never
+	// overwrites HTTPServer.ScraperController while a previous RunAll
+	// goroutine might still be running against it.
+	scraperMu     sync.Mutex
+	scraperCancel context.CancelFunc
+	scraperDone   chan struct{}
 }
 
 // NewMain initializes a new instance of Main with default values
@@ -144,18 +168,34 @@ func (m *Main) Run(ctx context.Context) (err error) {
 		return fmt.Errorf("cannot open db: %w", err)
 	}
 
-	// Instantiate services and attach them to the HTTP server
-	scrprService := postgres.NewScrprService(m.DB)
-	m.HTTPServer.ScraperController = *scrpr.NewController(2, scrprService)
-
 	// Copy config settings to the HTTP server
 	m.HTTPServer.Addr = m.Config.HTTP.Addr
 	m.HTTPServer.Domain = m.Config.HTTP.Domain
+	m.HTTPServer.Domains = m.Config.HTTP.Domains
 	m.HTTPServer.HashKey = m.Config.HTTP.HashKey
 	m.HTTPServer.BlockKey = m.Config.HTTP.BlockKey
 
-	// Attach services to the HTTP server
-	m.HTTPServer.ScrprService = scrprService // Fixed typo: was m.HTTPServer.sScrprService
+	switch {
+	case m.Config.HTTP.CertFile != "" && m.Config.HTTP.WatchCertFile:
+		m.HTTPServer.TLSProvider = &http.WatchedFileProvider{CertFile: m.Config.HTTP.CertFile, KeyFile: m.Config.HTTP.KeyFile}
+	case m.Config.HTTP.CertFile != "":
+		m.HTTPServer.TLSProvider = &http.StaticFileProvider{CertFile: m.Config.HTTP.CertFile, KeyFile: m.Config.HTTP.KeyFile}
+	case m.Config.HTTP.ACMEStaging:
+		domains := m.Config.HTTP.Domains
+		if len(domains) == 0 {
+			domains = []string{m.Config.HTTP.Domain}
+		}
+		m.HTTPServer.TLSProvider = http.LetsEncryptStagingProvider(domains, m.Config.HTTP.ACMECacheDir)
+	}
+
+	// Mount any static file directories declared in the config
+	for _, static := range m.Config.HTTP.Static {
+		m.HTTPServer.RegisterStatic(static.Prefix, static.Dir, http.StaticOptions{
+			ListDirectories: static.ListDirectories,
+			SPAFallback:     static.SPAFallback,
+			CacheSize:       static.CacheSize,
+		})
+	}
 
 	// Start the HTTP server
 	if err := m.HTTPServer.Open(); err != nil {
@@ -165,26 +205,185 @@ func (m *Main) Run(ctx context.Context) (err error) {
 	// If TLS is enabled, redirect non-TLS connections to TLS
 	if m.HTTPServer.UseTLS() {
 		go func() {
-			log.Fatal(http.ListenAndServeTLSRedirect(m.Config.HTTP.Domain))
+			log.Fatal(http.ListenAndServeRedirect(m.Config.HTTP.RedirectAddr, m.Config.HTTP.Domain))
 		}()
 	}
 
-	// Run all scrapers in the background
-	go m.HTTPServer.ScraperController.RunAll(ctx)
+	// Start the scrapers in the background
+	m.startScraper(ctx)
 
 	// Start the debug HTTP server for performance profiling
 	go func() { http.ListenAndServeDebug() }()
 
+	// Watch the config file and the routes/redirects tables for changes so
+	// operators don't have to bounce the process for everything
+	go func() {
+		if err := m.WatchAndReload(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("watch and reload: %s", err)
+		}
+	}()
+
 	// Log the running server details
 	log.Printf("running: url=%q debug=http://localhost:6060 dsn=%q", m.HTTPServer.URL(), m.Config.DB.DSN)
 
 	return nil
 }
 
+// startScraper (re)starts the scraper controller against a freshly-built
+// scrprService. If a controller from a previous call is still running, it's
+// canceled and waited out first, so HTTPServer.ScraperController is never
+// overwritten while the old RunAll goroutine might still be reading it.
+func (m *Main) startScraper(ctx context.Context) {
+	m.scraperMu.Lock()
+	defer m.scraperMu.Unlock()
+
+	if m.scraperCancel != nil {
+		m.scraperCancel()
+		<-m.scraperDone
+	}
+
+	scraperCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+
+	scrprService := postgres.NewScrprService(m.DB)
+	m.HTTPServer.ScraperController = *scrpr.NewController(2, scrprService)
+	m.HTTPServer.ScrprService = scrprService
+
+	m.scraperCancel = cancel
+	m.scraperDone = done
+
+	go func() {
+		defer close(done)
+		m.HTTPServer.ScraperController.RunAll(scraperCtx)
+	}()
+}
+
+// WatchAndReload watches the config file for writes and listens for
+// Postgres NOTIFY events on the "routes_changed" channel, calling Reload
+// whenever either fires. It blocks until ctx is canceled.
+func (m *Main) WatchAndReload(ctx context.Context) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("cannot create config watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	configPath, err := expand(m.ConfigPath)
+	if err != nil {
+		return err
+	}
+
+	if err := watcher.Add(filepath.Dir(configPath)); err != nil {
+		return fmt.Errorf("cannot watch config dir: %w", err)
+	}
+
+	routeCh := make(chan struct{}, 1)
+	go func() {
+		if err := m.DB.Listen(ctx, "routes_changed", func() { routeCh <- struct{}{} }); err != nil && ctx.Err() == nil {
+			log.Printf("listen routes_changed: %s", err)
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if filepath.Clean(event.Name) != filepath.Clean(configPath) || event.Op&(fsnotify.Write|fsnotify.Create) == 0 {
+				continue
+			}
+			if err := m.Reload(ctx); err != nil {
+				log.Printf("reload: %s", err)
+			}
+		case _, ok := <-routeCh:
+			if !ok {
+				return nil
+			}
+			if err := m.Reload(ctx); err != nil {
+				log.Printf("reload: %s", err)
+			}
+		}
+	}
+}
+
+// Reload re-reads the config file and calls http.Server.Reload, which swaps
+// Domain, Domains, HashKey, BlockKey and a freshly built router (covering
+// the reloaded static mounts) in place under the RWMutex that guards them;
+// serveHTTP reads the current router through the same lock, so in-flight
+// requests are unaffected by the swap. The scraper controller is restarted
+// separately since it isn't part of that swap. A change to HTTP.Addr can't
+// take effect without rebinding the listener, so it only logs a warning; a
+// change to the TLS provider settings likewise requires a restart, since
+// TLS is already bound to whichever provider was configured at Open (see
+// ReloadConfig.TLSProvider), so Reload doesn't attempt to pass one here.
+func (m *Main) Reload(ctx context.Context) error {
+	configPath, err := expand(m.ConfigPath)
+	if err != nil {
+		return err
+	}
+
+	oldAddr := m.Config.HTTP.Addr
+
+	config, err := ReadConfigFile(configPath)
+	if err != nil {
+		return fmt.Errorf("cannot reload config: %w", err)
+	}
+
+	if config.HTTP.Addr != oldAddr {
+		log.Printf("warning: http.addr changed to %q, restart required for it to take effect", config.HTTP.Addr)
+	}
+
+	m.Config = config
+
+	if dsn, err := expandDSN(config.DB.DSN); err != nil {
+		return fmt.Errorf("cannot expand dsn: %w", err)
+	} else if err := m.DB.SetDSN(dsn); err != nil {
+		return fmt.Errorf("cannot apply dsn: %w", err)
+	}
+
+	statics := make([]http.StaticMount, len(config.HTTP.Static))
+	for i, static := range config.HTTP.Static {
+		statics[i] = http.StaticMount{
+			Prefix: static.Prefix,
+			Dir:    static.Dir,
+			Opts: http.StaticOptions{
+				ListDirectories: static.ListDirectories,
+				SPAFallback:     static.SPAFallback,
+				CacheSize:       static.CacheSize,
+			},
+		}
+	}
+	router, staticPrefixes := m.HTTPServer.BuildRouter(statics)
+
+	// Reload swaps the router, Domain, Domains, HashKey and BlockKey in
+	// place under the RWMutex inside http.Server; serveHTTP reads them
+	// through the same lock, so in-flight requests are unaffected.
+	m.HTTPServer.Reload(http.ReloadConfig{
+		Router:         router,
+		StaticPrefixes: staticPrefixes,
+		Domain:         config.HTTP.Domain,
+		Domains:        config.HTTP.Domains,
+		HashKey:        config.HTTP.HashKey,
+		BlockKey:       config.HTTP.BlockKey,
+	})
+
+	// Restart the scraper controller against a service built from the
+	// reloaded DSN.
+	m.startScraper(ctx)
+
+	log.Printf("reloaded config from %s", configPath)
+
+	return nil
+}
+
 // Default paths and configuration values
 const (
-	DefaultConfigPath = "./betwiz.conf"
-	DefaultDSN        = "~/.betwiz/db"
+	DefaultConfigPath   = "./betwiz.conf"
+	DefaultDSN          = "~/.betwiz/db"
+	DefaultRedirectAddr = ":80"
 )
 
 // Config struct holds the application's configuration settings
@@ -198,13 +397,45 @@ type Config struct {
 		Domain   string `toml:"domain"`
 		HashKey  string `toml:"hash-key"`
 		BlockKey string `toml:"block-key"`
+
+		// Domains lists every hostname autocert should issue a cert for;
+		// it defaults to []string{Domain} when empty.
+		Domains []string `toml:"domains"`
+
+		// RedirectAddr is where the HTTP->HTTPS redirect listener binds;
+		// it defaults to ":80" in ReadConfigFile when left blank.
+		RedirectAddr string `toml:"redirect-addr"`
+
+		// CertFile/KeyFile select the static-file TLS provider instead of
+		// autocert; WatchCertFile additionally reloads them on change.
+		CertFile      string `toml:"cert-file"`
+		KeyFile       string `toml:"key-file"`
+		WatchCertFile bool   `toml:"watch-cert-file"`
+
+		// ACMEStaging points autocert at the Let's Encrypt staging
+		// directory instead of production.
+		ACMEStaging  bool   `toml:"acme-staging"`
+		ACMECacheDir string `toml:"acme-cache-dir"`
+
+		Static []StaticConfig `toml:"static"`
 	} `toml:"http"`
 }
 
+// StaticConfig declares one [[http.static]] mount: Dir is served under
+// Prefix, with the options forwarded as-is to Server.RegisterStatic.
+type StaticConfig struct {
+	Prefix          string `toml:"prefix"`
+	Dir             string `toml:"dir"`
+	ListDirectories bool   `toml:"list-directories"`
+	SPAFallback     bool   `toml:"spa-fallback"`
+	CacheSize       int    `toml:"cache-size"`
+}
+
 // DefaultConfig returns the default configuration values
 func DefaultConfig() Config {
 	var config Config
 	config.DB.DSN = DefaultDSN  // Fixed typo: was DNS instead of DSN
+	config.HTTP.RedirectAddr = DefaultRedirectAddr
 
 	return config
 }
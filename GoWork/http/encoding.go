@@ -0,0 +1,242 @@
+package http
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Encoder renders a value onto an HTTP response in a particular media type.
+type Encoder interface {
+	Encode(w io.Writer, v interface{}) error
+	ContentType() string
+}
+
+type registeredEncoder struct {
+	mediaType string
+	enc       Encoder
+}
+
+// RegisterEncoder adds enc to the content negotiation registry under
+// mediaType, and makes ext (e.g. ".json") rewrite to it the same way the
+// built-in extensions do in serveHTTP.
+func (s *Server) RegisterEncoder(mediaType, ext string, enc Encoder) {
+	if s.encoders == nil {
+		s.encoders = make(map[string]registeredEncoder)
+	}
+	s.encoders[mediaType] = registeredEncoder{mediaType: mediaType, enc: enc}
+
+	if s.extMediaTypes == nil {
+		s.extMediaTypes = make(map[string]string)
+	}
+	s.extMediaTypes[ext] = mediaType
+}
+
+func (s *Server) registerDefaultEncoders() {
+	s.RegisterEncoder("application/json", ".json", jsonEncoder{})
+	s.RegisterEncoder("text/csv", ".csv", csvEncoder{})
+	s.RegisterEncoder("application/xml", ".xml", xmlEncoder{})
+	s.RegisterEncoder("application/x-msgpack", ".msgpack", msgpackEncoder{})
+}
+
+// Respond picks an encoder for v based on the request's extension rewrite
+// (set in serveHTTP) or its Accept header, falling back to JSON, and writes
+// the encoded body with the matching Content-Type. If the chosen encoder
+// can't represent v at all (e.g. CSV asked to encode something other than
+// []map[string]string/[][]string), the returned error is an *encodeError so
+// wrap can report it as 406 Not Acceptable instead of a 500.
+func (s *Server) Respond(w http.ResponseWriter, r *http.Request, v interface{}) error {
+	re := s.encoderFor(r)
+
+	var buf bytes.Buffer
+	if err := re.enc.Encode(&buf, v); err != nil {
+		return &encodeError{mediaType: re.enc.ContentType(), err: err}
+	}
+
+	w.Header().Set("Content-Type", re.enc.ContentType())
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// encodeError marks a Respond failure as the chosen encoder being unable to
+// represent the value, as opposed to a write failure, so wrap can tell the
+// two apart.
+type encodeError struct {
+	mediaType string
+	err       error
+}
+
+func (e *encodeError) Error() string {
+	return fmt.Sprintf("encode as %s: %s", e.mediaType, e.err)
+}
+
+func (e *encodeError) Unwrap() error { return e.err }
+
+func (s *Server) encoderFor(r *http.Request) registeredEncoder {
+	for _, mediaType := range parseAccept(r.Header.Get("Accept")) {
+		if re, ok := s.encoders[mediaType]; ok {
+			return re
+		}
+	}
+
+	return registeredEncoder{mediaType: "application/json", enc: jsonEncoder{}}
+}
+
+// parseAccept splits an Accept header into media types ordered from highest
+// to lowest q-value, dropping the */* wildcard since it never identifies a
+// specific registered encoder.
+func parseAccept(header string) []string {
+	type weighted struct {
+		mediaType string
+		q         float64
+	}
+
+	var parsed []weighted
+	for _, part := range strings.Split(header, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		fields := strings.Split(part, ";")
+		mediaType := strings.TrimSpace(fields[0])
+		if mediaType == "" || mediaType == "*/*" {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if v, ok := strings.CutPrefix(param, "q="); ok {
+				if f, err := strconv.ParseFloat(v, 64); err == nil {
+					q = f
+				}
+			}
+		}
+
+		parsed = append(parsed, weighted{mediaType: mediaType, q: q})
+	}
+
+	if len(parsed) == 0 {
+		return nil
+	}
+
+	sort.SliceStable(parsed, func(i, j int) bool { return parsed[i].q > parsed[j].q })
+
+	mediaTypes := make([]string, len(parsed))
+	for i, p := range parsed {
+		mediaTypes[i] = p.mediaType
+	}
+	return mediaTypes
+}
+
+// APIHandlerFunc is an HTTP handler that returns a value to encode instead
+// of writing to the response directly, so every JSON/CSV/XML/MessagePack
+// response in the module goes through the same content negotiation path.
+type APIHandlerFunc func(w http.ResponseWriter, r *http.Request) (interface{}, error)
+
+// wrap adapts fn into an http.HandlerFunc that encodes its return value with
+// Respond, or reports the error as a JSON problem body.
+func (s *Server) wrap(fn APIHandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		v, err := fn(w, r)
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusInternalServerError)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+
+		if v == nil {
+			return
+		}
+
+		if err := s.Respond(w, r, v); err != nil {
+			var encErr *encodeError
+			if errors.As(err, &encErr) {
+				http.Error(w, encErr.Error(), http.StatusNotAcceptable)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	}
+}
+
+type jsonEncoder struct{}
+
+func (jsonEncoder) ContentType() string { return "application/json" }
+func (jsonEncoder) Encode(w io.Writer, v interface{}) error {
+	return json.NewEncoder(w).Encode(v)
+}
+
+type xmlEncoder struct{}
+
+func (xmlEncoder) ContentType() string { return "application/xml" }
+func (xmlEncoder) Encode(w io.Writer, v interface{}) error {
+	return xml.NewEncoder(w).Encode(v)
+}
+
+type msgpackEncoder struct{}
+
+func (msgpackEncoder) ContentType() string { return "application/x-msgpack" }
+func (msgpackEncoder) Encode(w io.Writer, v interface{}) error {
+	return msgpack.NewEncoder(w).Encode(v)
+}
+
+// csvEncoder encodes a []map[string]string or [][]string as CSV; anything
+// else is rejected since CSV has no natural representation for arbitrary
+// values.
+type csvEncoder struct{}
+
+func (csvEncoder) ContentType() string { return "text/csv" }
+func (csvEncoder) Encode(w io.Writer, v interface{}) error {
+	cw := csv.NewWriter(w)
+	defer cw.Flush()
+
+	switch rows := v.(type) {
+	case [][]string:
+		return cw.WriteAll(rows)
+	case []map[string]string:
+		return encodeCSVRecords(cw, rows)
+	default:
+		return fmt.Errorf("csv: unsupported type %T", v)
+	}
+}
+
+func encodeCSVRecords(cw *csv.Writer, rows []map[string]string) error {
+	if len(rows) == 0 {
+		return nil
+	}
+
+	header := make([]string, 0, len(rows[0]))
+	for k := range rows[0] {
+		header = append(header, k)
+	}
+	sort.Strings(header)
+
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		record := make([]string, len(header))
+		for i, k := range header {
+			record[i] = row[k]
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
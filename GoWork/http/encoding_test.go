@@ -0,0 +1,73 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestParseAccept(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   []string
+	}{
+		{
+			name:   "single type",
+			header: "application/json",
+			want:   []string{"application/json"},
+		},
+		{
+			name:   "q-values reorder by weight",
+			header: "application/xml;q=0.5, application/json;q=0.9, text/csv;q=0.7",
+			want:   []string{"application/json", "text/csv", "application/xml"},
+		},
+		{
+			name:   "missing q defaults to 1",
+			header: "application/json, text/csv;q=0.2",
+			want:   []string{"application/json", "text/csv"},
+		},
+		{
+			name:   "wildcard is dropped",
+			header: "*/*, application/json;q=0.9",
+			want:   []string{"application/json"},
+		},
+		{
+			name:   "empty header",
+			header: "",
+			want:   nil,
+		},
+		{
+			name:   "invalid q falls back to 1",
+			header: "application/json;q=nope",
+			want:   []string{"application/json"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseAccept(tt.header)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseAccept(%q) = %v, want %v", tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWrapUnencodableValueReturns406(t *testing.T) {
+	s := NewServer()
+	handler := s.wrap(func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+		return helloWorldResponse{Message: "hi"}, nil
+	})
+
+	r := httptest.NewRequest(http.MethodGet, "/helloworld", nil)
+	r.Header.Set("Accept", "text/csv")
+	w := httptest.NewRecorder()
+
+	handler(w, r)
+
+	if w.Code != http.StatusNotAcceptable {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusNotAcceptable)
+	}
+}
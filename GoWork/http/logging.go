@@ -0,0 +1,279 @@
+package http
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// AccessLogFormat selects how access log lines are rendered.
+type AccessLogFormat string
+
+const (
+	AccessLogFormatCombined AccessLogFormat = "combined"
+	AccessLogFormatJSON     AccessLogFormat = "json"
+)
+
+type contextKey int
+
+const requestIDContextKey contextKey = iota
+
+// RequestIDFromContext returns the X-Request-ID generated for the request
+// that ctx belongs to, or "" if ctx didn't come from a request handled by
+// the access log middleware.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDContextKey).(string)
+	return id
+}
+
+// responseRecorder captures the status code and byte count written through
+// it so the access log middleware can report them after the handler runs.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (rr *responseRecorder) WriteHeader(status int) {
+	rr.status = status
+	rr.ResponseWriter.WriteHeader(status)
+}
+
+func (rr *responseRecorder) Write(b []byte) (int, error) {
+	if rr.status == 0 {
+		rr.status = http.StatusOK
+	}
+	n, err := rr.ResponseWriter.Write(b)
+	rr.bytes += n
+	return n, err
+}
+
+// withAccessLog wraps next with a middleware that assigns each request an
+// ID, logs it in Combined Log Format or JSON (per s.AccessLogFormat) to
+// s.AccessLogWriter, and resolves the remote IP through X-Forwarded-For
+// when the peer address matches one of s.TrustedProxies.
+func (s *Server) withAccessLog(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := newRequestID()
+		w.Header().Set("X-Request-ID", requestID)
+		r = r.WithContext(context.WithValue(r.Context(), requestIDContextKey, requestID))
+
+		rr := &responseRecorder{ResponseWriter: w}
+		start := time.Now()
+
+		next.ServeHTTP(rr, r)
+
+		if rr.status == 0 {
+			rr.status = http.StatusOK
+		}
+
+		s.writeAccessLog(accessLogEntry{
+			RemoteIP:  s.remoteIP(r),
+			Method:    r.Method,
+			Path:      r.URL.RequestURI(),
+			Proto:     r.Proto,
+			Status:    rr.status,
+			Bytes:     rr.bytes,
+			Duration:  time.Since(start),
+			RequestID: requestID,
+			Time:      start,
+			Referer:   r.Referer(),
+			UserAgent: r.UserAgent(),
+		})
+	})
+}
+
+type accessLogEntry struct {
+	RemoteIP  string
+	Method    string
+	Path      string
+	Proto     string
+	Status    int
+	Bytes     int
+	Duration  time.Duration
+	RequestID string
+	Time      time.Time
+	Referer   string
+	UserAgent string
+}
+
+func (s *Server) writeAccessLog(e accessLogEntry) {
+	w := s.AccessLogWriter
+	if w == nil {
+		w = os.Stdout
+	}
+
+	if s.AccessLogFormat == AccessLogFormatJSON {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"remote_ip":  e.RemoteIP,
+			"method":     e.Method,
+			"path":       e.Path,
+			"proto":      e.Proto,
+			"status":     e.Status,
+			"bytes":      e.Bytes,
+			"duration_s": e.Duration.Seconds(),
+			"request_id": e.RequestID,
+			"time":       e.Time.Format(time.RFC3339),
+			"referer":    e.Referer,
+			"user_agent": e.UserAgent,
+		})
+		return
+	}
+
+	// Apache Combined Log Format: Common Log Format plus referer and
+	// user-agent.
+	fmt.Fprintf(w, "%s - - [%s] %q %d %d %q %q\n",
+		e.RemoteIP,
+		e.Time.Format("02/Jan/2006:15:04:05 -0700"),
+		fmt.Sprintf("%s %s %s", e.Method, e.Path, e.Proto),
+		e.Status,
+		e.Bytes,
+		emptyDash(e.Referer),
+		emptyDash(e.UserAgent),
+	)
+}
+
+// emptyDash renders an empty header value as "-", matching what Apache
+// itself prints when Referer or User-Agent is absent.
+func emptyDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// remoteIP returns r's client IP, preferring the left-most X-Forwarded-For
+// entry when r.RemoteAddr belongs to a trusted proxy.
+func (s *Server) remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	if !s.isTrustedProxy(host) {
+		return host
+	}
+
+	if fwd := r.Header.Get("X-Forwarded-For"); fwd != "" {
+		parts := strings.Split(fwd, ",")
+		return strings.TrimSpace(parts[0])
+	}
+
+	return host
+}
+
+func (s *Server) isTrustedProxy(host string) bool {
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+
+	for _, cidr := range s.TrustedProxies {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			continue
+		}
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func newRequestID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// nonCompressibleContentTypes are already-compressed or streaming media
+// types that gain nothing from gzip and cost CPU to try.
+var nonCompressibleContentTypes = []string{
+	"image/", "video/", "audio/",
+	"application/gzip", "application/zip", "application/x-gzip",
+}
+
+// gzipResponseWriter defers the decision to compress until the handler
+// actually sends a status, so responses with no body (204, 304, 1xx) never
+// get a gzip stream written into them, and a Content-Length the handler set
+// for the uncompressed body is dropped before it becomes a lie.
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	gz          *gzip.Writer
+	wroteHeader bool
+	compress    bool
+}
+
+func (w *gzipResponseWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+
+	w.compress = status != http.StatusNoContent &&
+		status != http.StatusNotModified &&
+		status >= 200 &&
+		w.Header().Get("Content-Encoding") == "" &&
+		!isNonCompressible(w.Header().Get("Content-Type"))
+
+	if w.compress {
+		w.Header().Del("Content-Length")
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Header().Add("Vary", "Accept-Encoding")
+		w.gz = gzip.NewWriter(w.ResponseWriter)
+	}
+
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *gzipResponseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.compress {
+		return w.gz.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *gzipResponseWriter) Close() error {
+	if w.gz == nil {
+		return nil
+	}
+	return w.gz.Close()
+}
+
+func isNonCompressible(contentType string) bool {
+	for _, prefix := range nonCompressibleContentTypes {
+		if strings.HasPrefix(contentType, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// withGzip compresses the response body when the client advertises gzip
+// support via Accept-Encoding, skipping responses that have no body or are
+// already compressed.
+func (s *Server) withGzip(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		gzw := &gzipResponseWriter{ResponseWriter: w}
+		defer gzw.Close()
+
+		next.ServeHTTP(gzw, r)
+	})
+}
@@ -0,0 +1,62 @@
+package http
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestServerRemoteIP(t *testing.T) {
+	tests := []struct {
+		name           string
+		trustedProxies []string
+		remoteAddr     string
+		forwardedFor   string
+		want           string
+	}{
+		{
+			name:         "no proxy configured ignores X-Forwarded-For",
+			remoteAddr:   "203.0.113.5:4242",
+			forwardedFor: "198.51.100.9",
+			want:         "203.0.113.5",
+		},
+		{
+			name:           "trusted proxy honors left-most forwarded address",
+			trustedProxies: []string{"10.0.0.0/8"},
+			remoteAddr:     "10.0.0.1:4242",
+			forwardedFor:   "198.51.100.9, 10.0.0.1",
+			want:           "198.51.100.9",
+		},
+		{
+			name:           "untrusted peer ignores X-Forwarded-For",
+			trustedProxies: []string{"10.0.0.0/8"},
+			remoteAddr:     "203.0.113.5:4242",
+			forwardedFor:   "198.51.100.9",
+			want:           "203.0.113.5",
+		},
+		{
+			name:           "trusted proxy with no header falls back to peer",
+			trustedProxies: []string{"10.0.0.0/8"},
+			remoteAddr:     "10.0.0.1:4242",
+			want:           "10.0.0.1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Server{TrustedProxies: tt.trustedProxies}
+
+			r, err := http.NewRequest(http.MethodGet, "/", nil)
+			if err != nil {
+				t.Fatalf("NewRequest: %v", err)
+			}
+			r.RemoteAddr = tt.remoteAddr
+			if tt.forwardedFor != "" {
+				r.Header.Set("X-Forwarded-For", tt.forwardedFor)
+			}
+
+			if got := s.remoteIP(r); got != tt.want {
+				t.Errorf("remoteIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package http
+
+import (
+	"context"
+	"log"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/soheilhy/cmux"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+// serveMux peeks the first bytes of every connection accepted on s.ln and
+// dispatches it to the right protocol handler so that HTTP/1.1, HTTPS,
+// h2c and gRPC can all share the one listener: TLS-looking bytes go to a
+// TLS-wrapped copy of the gorilla router, the HTTP/2 cleartext preface goes
+// to an h2c handler, gRPC requests go straight to s.GRPCServer, and
+// everything else falls through to the plain HTTP/1.1 handler. The TLS
+// matcher is only registered when UseTLS is true; otherwise cmux has no
+// matcher for a stray TLS ClientHello and its connection is closed instead
+// of being handed to a goroutine that would just return immediately.
+func (s *Server) serveMux() error {
+	s.cm = cmux.New(s.ln)
+	s.cm.SetReadTimeout(s.readHeaderTimeout())
+
+	var tlsL net.Listener
+	if s.UseTLS() {
+		tlsL = s.cm.Match(cmux.TLS())
+	}
+	grpcL := s.cm.Match(cmux.HTTP2HeaderField("content-type", "application/grpc"))
+	h2cL := s.cm.Match(cmux.HTTP2())
+	httpL := s.cm.Match(cmux.HTTP1Fast())
+
+	h2cServer := &http.Server{Handler: h2c.NewHandler(s.server.Handler, &http2.Server{})}
+
+	go s.GRPCServer.Serve(grpcL)
+	go h2cServer.Serve(h2cL)
+	go s.server.Serve(httpL)
+	if tlsL != nil {
+		go s.serveTLS(tlsL)
+	}
+
+	go s.cm.Serve()
+
+	return nil
+}
+
+// serveTLS terminates TLS on connections cmux has already identified as TLS
+// using whichever TLSProvider is configured, then serves the decrypted
+// stream with the same handler used for plain HTTP/1.1 and h2c. Callers only
+// reach this once UseTLS is known to be true, since serveMux doesn't
+// register a TLS matcher otherwise.
+func (s *Server) serveTLS(ln net.Listener) {
+	tlsLn, err := s.tlsProvider().Listener(ln)
+	if err != nil {
+		log.Printf("tls listener: %s", err)
+		return
+	}
+
+	if err := s.server.Serve(tlsLn); err != nil && err != http.ErrServerClosed {
+		log.Printf("tls accept: %s", err)
+	}
+}
+
+func (s *Server) readHeaderTimeout() time.Duration {
+	if s.ReadHeaderTimeout > 0 {
+		return s.ReadHeaderTimeout
+	}
+	return DefaultReadHeaderTimeout
+}
+
+// mountGateways runs every hook registered via RegisterGateway against a
+// shared *runtime.ServeMux and mounts the result under "/api/" so REST
+// clients can reach gRPC services through the same port.
+func (s *Server) mountGateways(ctx context.Context) error {
+	if len(s.gateways) == 0 {
+		return nil
+	}
+
+	gwmux := runtime.NewServeMux()
+	for _, fn := range s.gateways {
+		if err := fn(ctx, gwmux); err != nil {
+			return err
+		}
+	}
+
+	s.router.PathPrefix("/api/").Handler(gwmux)
+
+	return nil
+}
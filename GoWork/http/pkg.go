@@ -1,6 +1,7 @@
 package http
 
 import (
+	"encoding/xml"
 	"fmt"
 	"net/http"
 
@@ -10,9 +11,17 @@ import (
 //routes for pkg
 
 func (s *Server) registerPkgRoutes(r *mux.Router) {
-	r.HandleFunc("/helloworld", s.handleHelloWorld).Methods("GET")
+	r.HandleFunc("/helloworld", s.wrap(s.handleHelloWorld)).Methods("GET")
 }
 
-func (s *Server) handleHelloWorld(w http.ResponseWriter, r *http.Request) {
-	fmt.Println("Hello World!")
+// helloWorldResponse is the body of GET /helloworld; the XML tag gives it a
+// root element name since encoding/xml can't marshal a bare map.
+type helloWorldResponse struct {
+	XMLName xml.Name `xml:"helloworld" json:"-"`
+	Message string   `xml:"message" json:"message"`
+}
+
+func (s *Server) handleHelloWorld(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+	fmt.Printf("Hello World! request_id=%s\n", RequestIDFromContext(r.Context()))
+	return helloWorldResponse{Message: "Hello World!"}, nil
 }
@@ -5,39 +5,93 @@ package http
 import (
 	"context"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"path"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gorilla/mux"
-	"golang.org/x/crypto/acme/autocert"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/soheilhy/cmux"
+	"google.golang.org/grpc"
 )
 
 const ShutdownTimeout = 1 * time.Second
 
+// DefaultReadHeaderTimeout bounds how long the connection multiplexer will
+// wait for enough bytes to decide which protocol a new connection speaks,
+// so a slow-loris client can't tie up a dispatch goroutine forever.
+const DefaultReadHeaderTimeout = 5 * time.Second
+
 type Server struct {
 	ln     net.Listener
+	cm     cmux.CMux
 	server *http.Server
+
+	// mu guards router, staticPrefixes, Domain, Domains, TLSProvider,
+	// HashKey and BlockKey so Reload can swap them in place while serveHTTP
+	// and Open's TLS dispatch keep reading a consistent snapshot.
+	mu     sync.RWMutex
 	router *mux.Router
 
 	Addr   string
 	Domain string
 
+	// Domains lists every hostname autocert should issue for; it defaults
+	// to []string{Domain} when empty.
+	Domains []string
+
+	// TLSProvider overrides how TLS is terminated; it defaults to an
+	// AutocertProvider built from Domain/Domains when nil.
+	TLSProvider TLSProvider
+
 	HashKey  string
 	BlockKey string
 
+	// GRPCServer is served on the same listener as the HTTP traffic; it is
+	// created lazily by Open() if left nil.
+	GRPCServer *grpc.Server
+
+	// ReadHeaderTimeout bounds how long the cmux dispatch loop will wait to
+	// peek a connection's first bytes before giving up on it.
+	ReadHeaderTimeout time.Duration
+
+	gateways []GatewayFunc
+
+	// AccessLogWriter receives one access log line per request; it defaults
+	// to os.Stdout when nil.
+	AccessLogWriter io.Writer
+	// AccessLogFormat selects Combined Log Format or JSON; it defaults to
+	// AccessLogFormatCombined when empty.
+	AccessLogFormat AccessLogFormat
+	// TrustedProxies lists CIDRs that are allowed to set X-Forwarded-For.
+	TrustedProxies []string
+
+	staticPrefixes []string
+
+	encoders      map[string]registeredEncoder
+	extMediaTypes map[string]string
+
 	//ScraperController
 }
 
+// GatewayFunc registers gRPC-gateway handlers against mux so that REST
+// requests can be served over HTTP/1.1 alongside the native gRPC service.
+type GatewayFunc func(ctx context.Context, mux *runtime.ServeMux) error
+
 func NewServer() *Server {
 	s := &Server{
-		server: &http.Server{},
-		router: mux.NewRouter(),
+		server:            &http.Server{},
+		router:            mux.NewRouter(),
+		ReadHeaderTimeout: DefaultReadHeaderTimeout,
 	}
 
-	s.server.Handler = http.HandlerFunc(s.serveHTTP)
+	s.server.Handler = s.withGzip(s.withAccessLog(http.HandlerFunc(s.serveHTTP)))
+
+	s.registerDefaultEncoders()
 
 	{
 		r := s.router.PathPrefix("/").Subrouter()
@@ -47,8 +101,39 @@ func NewServer() *Server {
 	return s
 }
 
+// RegisterGateway adds a gRPC-gateway registration hook. Hooks are invoked
+// in Open() against the same context that governs the server's lifetime, and
+// the resulting REST routes are mounted under the "/api/" prefix of the
+// gorilla router.
+func (s *Server) RegisterGateway(fn GatewayFunc) {
+	s.gateways = append(s.gateways, fn)
+}
+
+// UseTLS reports whether Open/serveMux should terminate TLS at all: either
+// an explicit TLSProvider was configured, or Domain is set so the default
+// AutocertProvider applies.
 func (s *Server) UseTLS() bool {
-	return s.Domain != ""
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.TLSProvider != nil || s.Domain != ""
+}
+
+// tlsProvider returns s.TLSProvider, falling back to an AutocertProvider
+// built from Domain/Domains so existing callers that only set Domain keep
+// working unchanged.
+func (s *Server) tlsProvider() TLSProvider {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.TLSProvider != nil {
+		return s.TLSProvider
+	}
+
+	domains := s.Domains
+	if len(domains) == 0 {
+		domains = []string{s.Domain}
+	}
+	return &AutocertProvider{Domains: domains}
 }
 
 func (s *Server) Scheme() string {
@@ -70,9 +155,12 @@ func (s *Server) Port() int {
 func (s *Server) URL() string {
 	scheme, port := s.Scheme(), s.Port()
 
-	domain := "localhost"
-	if s.Domain != "" {
-		domain = s.Domain
+	s.mu.RLock()
+	domain := s.Domain
+	s.mu.RUnlock()
+
+	if domain == "" {
+		domain = "localhost"
 	}
 
 	if (scheme == "http" && port == 80) || (scheme == "https" && port == 443) {
@@ -90,34 +178,120 @@ func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	switch ext := path.Ext(r.URL.Path); ext {
-	case ".json":
-		r.Header.Set("Accept", "application/json")
-		r.Header.Set("Content-type", "application/json")
-		r.URL.Path = strings.TrimSuffix(r.URL.Path, ext)
-	case ".csv":
-		r.Header.Set("Accept", "text/csv")
-		r.URL.Path = strings.TrimSuffix(r.URL.Path, ext)
+	s.mu.RLock()
+	router := s.router
+	staticPrefixes := s.staticPrefixes
+	s.mu.RUnlock()
+
+	if isStaticPath(staticPrefixes, r.URL.Path) {
+		router.ServeHTTP(w, r)
+		return
+	}
+
+	if ext := path.Ext(r.URL.Path); ext != "" {
+		if mediaType, ok := s.extMediaTypes[ext]; ok {
+			r.Header.Set("Accept", mediaType)
+			r.Header.Set("Content-type", mediaType)
+			r.URL.Path = strings.TrimSuffix(r.URL.Path, ext)
+		}
 	}
 
-	s.router.ServeHTTP(w, r)
+	router.ServeHTTP(w, r)
+}
+
+// BuildRouter assembles a fresh router carrying the package routes plus the
+// given static mounts, without touching the live router or staticPrefixes.
+// Callers that want Reload to rebuild routes (e.g. because the static mount
+// list changed) build one of these and pass it, and its matching prefixes,
+// through ReloadConfig so the two stay consistent with each other.
+func (s *Server) BuildRouter(statics []StaticMount) (router *mux.Router, prefixes []string) {
+	router = mux.NewRouter()
+	{
+		r := router.PathPrefix("/").Subrouter()
+		s.registerPkgRoutes(r)
+	}
+
+	prefixes = make([]string, 0, len(statics))
+	for _, m := range statics {
+		router.PathPrefix(m.Prefix).Handler(staticHandlerFor(m.Prefix, m.Dir, m.Opts))
+		prefixes = append(prefixes, m.Prefix)
+	}
+
+	return router, prefixes
+}
+
+// ReloadConfig carries the subset of Server state that Reload can swap in
+// place without rebinding the listener.
+type ReloadConfig struct {
+	// Router replaces the gorilla router serveHTTP dispatches to; nil
+	// leaves the current router in place. Build one with BuildRouter and
+	// set StaticPrefixes to the prefixes it returns so isStaticPath stays
+	// in sync with the new router.
+	Router         *mux.Router
+	StaticPrefixes []string
+
+	Domain   string
+	Domains  []string
+	HashKey  string
+	BlockKey string
+
+	// TLSProvider replaces s.TLSProvider for the next call to Open; nil
+	// leaves the current provider in place. It has no effect on a server
+	// that's already listening, since serveTLS binds the TLS listener once
+	// from tlsProvider() in serveMux and blocks in Serve from then on -- a
+	// provider swap while TLS is live requires a restart.
+	TLSProvider TLSProvider
+}
+
+// Reload swaps the router, TLS provider, domains and keys in place under
+// s.mu. serveHTTP reads s.router through the same lock, so in-flight
+// requests keep running against the router they started with while new
+// requests see cfg's router as soon as Reload returns. A change to Addr
+// can't take effect here since that requires rebinding the listener, and
+// likewise a TLSProvider swap has no effect on an already-open TLS listener
+// (see ReloadConfig.TLSProvider).
+func (s *Server) Reload(cfg ReloadConfig) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if cfg.Router != nil {
+		s.router = cfg.Router
+		s.staticPrefixes = cfg.StaticPrefixes
+	}
+
+	s.Domain = cfg.Domain
+	s.Domains = cfg.Domains
+	s.HashKey = cfg.HashKey
+	s.BlockKey = cfg.BlockKey
+
+	if cfg.TLSProvider != nil {
+		s.TLSProvider = cfg.TLSProvider
+	}
 }
 
 func (s *Server) Open() (err error) {
-	if s.Domain != "" {
-		s.ln = autocert.NewListener(s.Domain)
-	} else {
-		if s.ln, err = net.Listen("tcp", s.Addr); err != nil {
-			return err
-		}
+	if s.ln, err = net.Listen("tcp", s.Addr); err != nil {
+		return err
 	}
 
-	go s.server.Serve(s.ln)
+	if s.GRPCServer == nil {
+		s.GRPCServer = grpc.NewServer()
+	}
+
+	if err := s.mountGateways(context.Background()); err != nil {
+		return err
+	}
 
-	return nil
+	return s.serveMux()
 }
 
 func (s *Server) Close() error {
+	if s.cm != nil {
+		s.cm.Close()
+	}
+
+	s.GRPCServer.GracefulStop()
+
 	ctx, cancel := context.WithTimeout(context.Background(), ShutdownTimeout)
 	defer cancel()
 	return s.server.Shutdown(ctx)
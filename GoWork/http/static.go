@@ -0,0 +1,224 @@
+package http
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha1"
+	"encoding/hex"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StaticOptions configures a static mount registered with RegisterStatic.
+type StaticOptions struct {
+	// ListDirectories enables directory listings for paths without an
+	// index.html, mirroring http.FileServer's default behavior.
+	ListDirectories bool
+	// SPAFallback serves dir/index.html for any request that 404s and
+	// whose path has no file extension, so client-side routers keep working
+	// on a hard refresh.
+	SPAFallback bool
+	// CacheSize bounds how many small files are kept in the in-memory LRU;
+	// 0 disables the cache.
+	CacheSize int
+}
+
+const staticCacheMaxFileSize = 256 << 10 // 256KiB; larger files bypass the LRU.
+
+// StaticMount declares one directory mount for BuildRouter: Dir is served
+// under Prefix with the given options, mirroring a single RegisterStatic
+// call.
+type StaticMount struct {
+	Prefix string
+	Dir    string
+	Opts   StaticOptions
+}
+
+// RegisterStatic mounts dir under prefix on the gorilla router. Served files
+// honor If-Modified-Since; files served from the in-memory LRU (CacheSize > 0
+// and at most staticCacheMaxFileSize bytes) also get a weak ETag derived from
+// their content, while other files are sent without an ETag. The mount's
+// extension rewrite in serveHTTP is skipped so static paths aren't mistaken
+// for the .json/.csv convention.
+func (s *Server) RegisterStatic(prefix, dir string, opts StaticOptions) {
+	s.staticPrefixes = append(s.staticPrefixes, prefix)
+	s.router.PathPrefix(prefix).Handler(staticHandlerFor(prefix, dir, opts))
+}
+
+// staticHandlerFor builds the handler mounted under prefix, stripping prefix
+// off the request path before it reaches the underlying http.FileServer.
+func staticHandlerFor(prefix, dir string, opts StaticOptions) http.Handler {
+	handler := &staticHandler{
+		dir:  dir,
+		opts: opts,
+		fs:   http.FileServer(http.Dir(dir)),
+	}
+	if opts.CacheSize > 0 {
+		handler.cache = newStaticCache(opts.CacheSize)
+	}
+
+	return http.StripPrefix(strings.TrimSuffix(prefix, "/"), handler)
+}
+
+// isStaticPath reports whether p falls under one of prefixes, so serveHTTP
+// can leave its extension untouched for paths registered with RegisterStatic
+// or BuildRouter.
+func isStaticPath(prefixes []string, p string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(p, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+type staticHandler struct {
+	dir  string
+	opts StaticOptions
+	fs   http.Handler
+
+	cache *staticCache
+}
+
+func (h *staticHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := filepath.Join(h.dir, filepath.FromSlash(path.Clean(r.URL.Path)))
+
+	if !h.opts.ListDirectories {
+		if fi, err := os.Stat(name); err == nil && fi.IsDir() {
+			if _, err := os.Stat(filepath.Join(name, "index.html")); err != nil {
+				http.NotFound(w, r)
+				return
+			}
+		}
+	}
+
+	if h.serveCached(w, r, name) {
+		return
+	}
+
+	if h.opts.SPAFallback {
+		if _, err := os.Stat(name); err != nil && filepath.Ext(name) == "" {
+			http.ServeFile(w, r, filepath.Join(h.dir, "index.html"))
+			return
+		}
+	}
+
+	h.fs.ServeHTTP(w, r)
+}
+
+// serveCached serves name out of the in-memory LRU (populating it on miss),
+// setting ETag and honoring If-None-Match/If-Modified-Since. It reports
+// whether it handled the request.
+func (h *staticHandler) serveCached(w http.ResponseWriter, r *http.Request, name string) bool {
+	if h.cache == nil {
+		return false
+	}
+
+	entry, ok := h.cache.get(name)
+	if !ok {
+		buf, fi, err := readSmallFile(name)
+		if err != nil {
+			return false
+		}
+		entry = staticCacheEntry{body: buf, modTime: fi.ModTime(), etag: etagFor(buf)}
+		h.cache.put(name, entry)
+	}
+
+	w.Header().Set("ETag", entry.etag)
+	if r.Header.Get("If-None-Match") == entry.etag {
+		w.WriteHeader(http.StatusNotModified)
+		return true
+	}
+
+	http.ServeContent(w, r, name, entry.modTime, bytes.NewReader(entry.body))
+	return true
+}
+
+func readSmallFile(name string) ([]byte, os.FileInfo, error) {
+	fi, err := os.Stat(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	if fi.IsDir() || fi.Size() > staticCacheMaxFileSize {
+		return nil, nil, os.ErrInvalid
+	}
+	buf, err := os.ReadFile(name)
+	return buf, fi, err
+}
+
+// etagFor returns a weak validator: it's cheap to recompute from the cached
+// bytes, but two files with the same content hash aren't guaranteed to be
+// byte-identical at the semantic level http.ServeContent expects of a
+// strong ETag, so it's marked with the W/ prefix per RFC 7232 §2.3.
+func etagFor(buf []byte) string {
+	sum := sha1.Sum(buf)
+	return `W/"` + hex.EncodeToString(sum[:]) + `"`
+}
+
+// staticCacheEntry is one cached file's bytes, modification time and ETag.
+type staticCacheEntry struct {
+	body    []byte
+	modTime time.Time
+	etag    string
+}
+
+// staticCache is a small LRU of recently-served static files, guarded by a
+// mutex since requests are served concurrently.
+type staticCache struct {
+	mu       sync.Mutex
+	capacity int
+	ll       *list.List
+	items    map[string]*list.Element
+}
+
+type staticCacheItem struct {
+	key   string
+	entry staticCacheEntry
+}
+
+func newStaticCache(capacity int) *staticCache {
+	return &staticCache{
+		capacity: capacity,
+		ll:       list.New(),
+		items:    make(map[string]*list.Element),
+	}
+}
+
+func (c *staticCache) get(key string) (staticCacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		return staticCacheEntry{}, false
+	}
+	c.ll.MoveToFront(el)
+	return el.Value.(*staticCacheItem).entry, true
+}
+
+func (c *staticCache) put(key string, entry staticCacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		c.ll.MoveToFront(el)
+		el.Value.(*staticCacheItem).entry = entry
+		return
+	}
+
+	el := c.ll.PushFront(&staticCacheItem{key: key, entry: entry})
+	c.items[key] = el
+
+	if c.ll.Len() > c.capacity {
+		oldest := c.ll.Back()
+		if oldest != nil {
+			c.ll.Remove(oldest)
+			delete(c.items, oldest.Value.(*staticCacheItem).key)
+		}
+	}
+}
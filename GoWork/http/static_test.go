@@ -0,0 +1,42 @@
+package http
+
+import "testing"
+
+func TestStaticCacheEviction(t *testing.T) {
+	c := newStaticCache(2)
+
+	c.put("a", staticCacheEntry{etag: "a"})
+	c.put("b", staticCacheEntry{etag: "b"})
+
+	if _, ok := c.get("a"); !ok {
+		t.Fatalf("get(a) = false, want true before eviction")
+	}
+
+	// "a" is now most-recently-used; adding "c" should evict "b", not "a".
+	c.put("c", staticCacheEntry{etag: "c"})
+
+	if _, ok := c.get("b"); ok {
+		t.Errorf("get(b) = true, want false: least-recently-used entry should have been evicted")
+	}
+	if entry, ok := c.get("a"); !ok || entry.etag != "a" {
+		t.Errorf("get(a) = (%v, %v), want (a, true)", entry, ok)
+	}
+	if entry, ok := c.get("c"); !ok || entry.etag != "c" {
+		t.Errorf("get(c) = (%v, %v), want (c, true)", entry, ok)
+	}
+}
+
+func TestStaticCacheUpdateExisting(t *testing.T) {
+	c := newStaticCache(2)
+
+	c.put("a", staticCacheEntry{etag: "v1"})
+	c.put("a", staticCacheEntry{etag: "v2"})
+
+	entry, ok := c.get("a")
+	if !ok || entry.etag != "v2" {
+		t.Errorf("get(a) = (%v, %v), want (v2, true)", entry, ok)
+	}
+	if c.ll.Len() != 1 {
+		t.Errorf("cache has %d entries, want 1 after re-putting the same key", c.ll.Len())
+	}
+}
@@ -0,0 +1,162 @@
+package http
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// TLSProvider decides how a Server obtains its TLS listener and certificates.
+// Open() delegates to whichever provider is configured instead of assuming
+// ACME via autocert.
+type TLSProvider interface {
+	// Listener wraps ln (or returns it unchanged if TLS termination happens
+	// elsewhere) so Serve sees decrypted connections.
+	Listener(ln net.Listener) (net.Listener, error)
+}
+
+// AutocertProvider obtains certificates from Let's Encrypt (or whichever
+// ACME directory URL is set) for the given domains, caching them under
+// CacheDir.
+type AutocertProvider struct {
+	Domains  []string
+	CacheDir string
+	// DirectoryURL overrides the ACME directory; leave empty for production
+	// Let's Encrypt.
+	DirectoryURL string
+}
+
+func (p *AutocertProvider) manager() *autocert.Manager {
+	m := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(p.Domains...),
+	}
+	if p.CacheDir != "" {
+		m.Cache = autocert.DirCache(p.CacheDir)
+	}
+	if p.DirectoryURL != "" {
+		m.Client = &acme.Client{DirectoryURL: p.DirectoryURL}
+	}
+	return m
+}
+
+func (p *AutocertProvider) Listener(ln net.Listener) (net.Listener, error) {
+	return tls.NewListener(ln, p.manager().TLSConfig()), nil
+}
+
+// LetsEncryptStagingProvider is an AutocertProvider pre-wired to the
+// Let's Encrypt staging directory, for exercising the ACME flow in tests
+// and on dev domains without hitting production rate limits.
+func LetsEncryptStagingProvider(domains []string, cacheDir string) *AutocertProvider {
+	return &AutocertProvider{
+		Domains:      domains,
+		CacheDir:     cacheDir,
+		DirectoryURL: "https://acme-staging-v02.api.letsencrypt.org/directory",
+	}
+}
+
+// StaticFileProvider serves a fixed certificate/key pair read once at
+// startup.
+type StaticFileProvider struct {
+	CertFile string
+	KeyFile  string
+}
+
+func (p *StaticFileProvider) Listener(ln net.Listener) (net.Listener, error) {
+	cert, err := tls.LoadX509KeyPair(p.CertFile, p.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("load cert/key: %w", err)
+	}
+	return tls.NewListener(ln, &tls.Config{Certificates: []tls.Certificate{cert}}), nil
+}
+
+// WatchedFileProvider serves a certificate/key pair from disk, reloading the
+// pair whenever the certificate file's modification time changes so operators
+// can rotate certs without a restart. Only CertFile is polled: a change to
+// KeyFile alone does not trigger a reload.
+type WatchedFileProvider struct {
+	CertFile string
+	KeyFile  string
+	// PollInterval controls how often the files are checked; it defaults to
+	// 30 seconds.
+	PollInterval time.Duration
+
+	mu      sync.RWMutex
+	cert    *tls.Certificate
+	modTime time.Time
+}
+
+func (p *WatchedFileProvider) Listener(ln net.Listener) (net.Listener, error) {
+	if err := p.reload(); err != nil {
+		return nil, err
+	}
+	go p.watch()
+	return tls.NewListener(ln, &tls.Config{GetCertificate: p.getCertificate}), nil
+}
+
+func (p *WatchedFileProvider) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.cert, nil
+}
+
+func (p *WatchedFileProvider) reload() error {
+	cert, err := tls.LoadX509KeyPair(p.CertFile, p.KeyFile)
+	if err != nil {
+		return fmt.Errorf("load cert/key: %w", err)
+	}
+
+	fi, err := os.Stat(p.CertFile)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	p.cert = &cert
+	p.modTime = fi.ModTime()
+	p.mu.Unlock()
+
+	return nil
+}
+
+func (p *WatchedFileProvider) watch() {
+	interval := p.PollInterval
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+
+	for range time.Tick(interval) {
+		fi, err := os.Stat(p.CertFile)
+		if err != nil {
+			continue
+		}
+
+		p.mu.RLock()
+		unchanged := fi.ModTime().Equal(p.modTime)
+		p.mu.RUnlock()
+		if unchanged {
+			continue
+		}
+
+		_ = p.reload()
+	}
+}
+
+// ListenAndServeRedirect listens on addr and redirects every request to
+// https://domain, with the original path and query string preserved. addr
+// is read from config rather than assumed to be ":80" so operators can put
+// it behind a different port or interface.
+func ListenAndServeRedirect(addr, domain string) error {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		target := "https://" + domain + r.URL.RequestURI()
+		http.Redirect(w, r, target, http.StatusMovedPermanently)
+	})
+	return http.ListenAndServe(addr, handler)
+}